@@ -4,14 +4,12 @@ package mobilelib
 import (
 	"bufio"
 	"bytes"
-	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"errors"
 	"io"
 	"net"
 	"net/http"
-	"net/http/cookiejar"
 	"net/textproto"
 	"net/url"
 	"strings"
@@ -22,11 +20,20 @@ import (
 
 type Client struct {
 	http    *http.Client
-	jar     *cookiejar.Jar
+	jar     *CookieJar
 	ua      string
 	host    string // rutracker.me
 	mirrors []string // list of mirror URLs
-	currentMirror int // current mirror index for rotation
+	currentMirror int // index of the mirror used by the most recent request
+
+	mirrorHealth []*mirrorHealth // per-mirror circuit breaker + EWMA latency, parallel to mirrors
+	probeCancel  context.CancelFunc
+
+	retryPolicy  RetryPolicy
+	retryWaitMin time.Duration
+	retryWaitMax time.Duration
+	retryMax     int
+	retryLog     RetryLogFunc
 }
 
 // MirrorConfig holds configuration for mirror selection
@@ -35,11 +42,60 @@ type MirrorConfig struct {
 	MirrorHosts   []string
 	MaxRetries    int
 	Timeout       time.Duration
+
+	// RetryWaitMin and RetryWaitMax bound the exponential backoff sleep
+	// between attempts (default 500ms / 30s). RetryMax times the number of
+	// mirrors caps the total attempts per request, with pickMirror choosing
+	// the mirror for each attempt (default MaxRetries, or 3).
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+	RetryMax     int
+
+	// RetryLog, if set, is called after every attempt so callers can
+	// surface retry telemetry (e.g. to the Flutter UI).
+	RetryLog RetryLogFunc
+
+	// TLSFingerprint selects a browser ClientHello to mimic via uTLS
+	// instead of Go's own TLS stack: a named profile ("chrome-120",
+	// "firefox-esr", "safari-ios-17") or a raw JA3 string. Left empty
+	// (the default), the stdlib TLS stack is used unchanged.
+	//
+	// Known gap: only the TLS ClientHello is spoofed. The HTTP/2 SETTINGS
+	// frame sent once a connection negotiates "h2" is not reordered to
+	// match the chosen profile; see fingerprintRoundTripper.
+	TLSFingerprint string
+
+	// HealthProbeInterval, if positive, starts a background HEAD "/"
+	// probe of every mirror on this interval so a recovered mirror's
+	// circuit breaker closes again without waiting on user traffic.
+	HealthProbeInterval time.Duration
+
+	// PersistCookies, if non-empty, atomically flushes the cookie jar to
+	// this path on every Set-Cookie and reloads it here on startup, so an
+	// Android WebView login survives the app being killed.
+	PersistCookies string
+
+	// Proxy is the default proxy URL (http://, https://, socks5://, or
+	// socks5h:// for remote DNS) used for mirrors without a more specific
+	// entry in MirrorProxies. Empty means connect directly.
+	Proxy string
+
+	// MirrorProxies overrides Proxy per mirror host, e.g. routing a
+	// .onion mirror through Tor while another goes out directly.
+	MirrorProxies map[string]string
+
+	// AutoDetectTor probes 127.0.0.1:9050 and :9150 on init and, if
+	// either accepts a connection, routes any .onion mirror through it
+	// automatically (as if it were in MirrorProxies).
+	AutoDetectTor bool
 }
 
 // NewClientWithMirrors creates a new HTTP client with mirror support
 func NewClientWithMirrors(config MirrorConfig, userAgent string) (*Client, error) {
-	jar, _ := cookiejar.New(nil)
+	jar, err := newCookieJar(config.PersistCookies)
+	if err != nil {
+		return nil, err
+	}
 
 	// Transport "like a browser": HTTP/2, timeouts, TLS by default
 	transport := &http.Transport{
@@ -53,16 +109,53 @@ func NewClientWithMirrors(config MirrorConfig, userAgent string) (*Client, error
 		IdleConnTimeout:   90 * time.Second,
 	}
 
-	// Enable HTTP/2 if available
-	_ = http2.ConfigureTransport(transport)
+	var roundTripper http.RoundTripper = transport
+	if config.TLSFingerprint == "" {
+		// Enable HTTP/2 on the stdlib TLS stack (default, backward compatible).
+		_ = http2.ConfigureTransport(transport)
+	} else {
+		fpRoundTripper, err := newFingerprintRoundTripper(config.TLSFingerprint, transport)
+		if err != nil {
+			return nil, err
+		}
+		roundTripper = fpRoundTripper
+	}
+
+	if config.Proxy != "" || len(config.MirrorProxies) > 0 || config.AutoDetectTor {
+		roundTripper = &routingRoundTripper{
+			defaultTransport: roundTripper,
+			router:           newProxyRouter(transport, config),
+		}
+	}
 
 	// Prepare mirror list (primary host first)
 	mirrors := []string{config.PrimaryHost}
 	mirrors = append(mirrors, config.MirrorHosts...)
 
+	retryWaitMin := config.RetryWaitMin
+	if retryWaitMin <= 0 {
+		retryWaitMin = 500 * time.Millisecond
+	}
+	retryWaitMax := config.RetryWaitMax
+	if retryWaitMax <= 0 {
+		retryWaitMax = 30 * time.Second
+	}
+	retryMax := config.RetryMax
+	if retryMax <= 0 {
+		retryMax = config.MaxRetries
+	}
+	if retryMax <= 0 {
+		retryMax = 3
+	}
+
+	mirrorHealthList := make([]*mirrorHealth, len(mirrors))
+	for i, host := range mirrors {
+		mirrorHealthList[i] = &mirrorHealth{host: host}
+	}
+
 	c := &Client{
 		http: &http.Client{
-			Transport: transport,
+			Transport: roundTripper,
 			Jar:       jar,
 			Timeout:   config.Timeout,
 		},
@@ -71,10 +164,34 @@ func NewClientWithMirrors(config MirrorConfig, userAgent string) (*Client, error
 		host:    config.PrimaryHost,
 		mirrors: mirrors,
 		currentMirror: 0,
+
+		mirrorHealth: mirrorHealthList,
+
+		retryPolicy:  DefaultRetryPolicy(),
+		retryWaitMin: retryWaitMin,
+		retryWaitMax: retryWaitMax,
+		retryMax:     retryMax,
+		retryLog:     config.RetryLog,
+	}
+
+	if config.HealthProbeInterval > 0 {
+		probeCtx, cancel := context.WithCancel(context.Background())
+		c.probeCancel = cancel
+		c.startHealthProbes(probeCtx, config.HealthProbeInterval)
 	}
+
 	return c, nil
 }
 
+// Close stops the background mirror health prober, if one was started via
+// MirrorConfig.HealthProbeInterval. It is safe to call on a Client created
+// without probing enabled.
+func (c *Client) Close() {
+	if c.probeCancel != nil {
+		c.probeCancel()
+	}
+}
+
 // NewClient creates a simple client without mirror support (backward compatibility)
 func NewClient(host string, userAgent string) (*Client, error) {
 	config := MirrorConfig{
@@ -86,15 +203,6 @@ func NewClient(host string, userAgent string) (*Client, error) {
 	return NewClientWithMirrors(config, userAgent)
 }
 
-// rotateMirror switches to the next available mirror
-func (c *Client) rotateMirror() {
-	if len(c.mirrors) <= 1 {
-		return // no mirrors to rotate to
-	}
-	c.currentMirror = (c.currentMirror + 1) % len(c.mirrors)
-	c.host = c.mirrors[c.currentMirror]
-}
-
 // getCurrentHost returns the current host being used
 func (c *Client) getCurrentHost() string {
 	return c.host
@@ -120,80 +228,176 @@ func (c *Client) SetCookieString(cookieStr string, scheme string) error {
 	return nil
 }
 
-// GetText performs HTTP GET with automatic mirror rotation and retry logic
+// GetText performs HTTP GET with health-scored mirror selection and retry
+// logic, returning the decoded body as a string. It's a thin convenience
+// wrapper around Request(); use Request() directly for POSTs, JSON/form/
+// multipart bodies, or streaming large responses.
 func (c *Client) GetText(ctx context.Context, path string) (string, error) {
+	resp, err := c.Request().Path(path).Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resp.Text()
+}
+
+// requestSpec is the fully-resolved description of a single logical
+// request, built by RequestBuilder and executed (with retries and mirror
+// rotation) by doRequest.
+type requestSpec struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	cookies     []*http.Cookie
+	body        []byte
+	contentType string
+}
+
+// doRequest is the shared core behind GetText and RequestBuilder: it picks
+// a mirror by health score, sends the request, retries per c.retryPolicy
+// (rotating mirrors as pickMirror sees fit on each attempt), and on
+// success returns a Response with the body decompressed but not yet read.
+func (c *Client) doRequest(ctx context.Context, spec requestSpec) (*Response, error) {
+	path := spec.path
 	if !strings.HasPrefix(path, "/") {
 		path = "/" + path
 	}
 
 	var lastErr error
-	maxAttempts := 3 // attempts per mirror + mirror rotations
+	maxAttempts := c.retryMax * len(c.mirrors)
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
-		// If we've tried all mirrors, reset to primary
-		if attempt > 0 && attempt%len(c.mirrors) == 0 {
-			c.rotateMirror()
+		idx := c.pickMirror()
+		c.currentMirror = idx
+		c.host = c.mirrors[idx]
+
+		u := "https://" + c.host + path
+		if len(spec.query) > 0 {
+			u += "?" + spec.query.Encode()
+		}
+
+		var bodyReader io.Reader
+		if spec.body != nil {
+			bodyReader = bytes.NewReader(spec.body)
 		}
 
-		// Create request for current host
-		url := "https://" + c.host + path
-		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req, _ := http.NewRequestWithContext(ctx, spec.method, u, bodyReader)
 		req.Header.Set("User-Agent", c.ua)
 		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-		req.Header.Set("Accept-Encoding", "gzip")
+		req.Header.Set("Accept-Encoding", "gzip, deflate, br")
 		req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+		if spec.contentType != "" {
+			req.Header.Set("Content-Type", spec.contentType)
+		}
+		for k, vals := range spec.header {
+			for i, v := range vals {
+				if i == 0 {
+					// Set (not Add) the first value so an explicit
+					// .Header("Content-Type", ...) from the caller replaces
+					// the auto-derived one above instead of appending a
+					// second Content-Type header.
+					req.Header.Set(k, v)
+				} else {
+					req.Header.Add(k, v)
+				}
+			}
+		}
+		for _, ck := range spec.cookies {
+			req.AddCookie(ck)
+		}
 
+		start := time.Now()
 		resp, err := c.http.Do(req)
+		latency := time.Since(start)
+
+		shouldRetry, checkErr := c.retryPolicy.CheckRetry(ctx, resp, err)
+		if checkErr != nil {
+			c.recordMirrorResult(idx, false, latency)
+			if c.retryLog != nil {
+				c.retryLog(attempt, 0, resp, checkErr)
+			}
+			return nil, checkErr
+		}
+
 		if err != nil {
+			c.recordMirrorResult(idx, false, latency)
 			lastErr = err
-			// If connection failed, try next mirror immediately
-			if strings.Contains(err.Error(), "connection refused") ||
-			   strings.Contains(err.Error(), "timeout") ||
-			   strings.Contains(err.Error(), "no such host") {
-				c.rotateMirror()
-				continue
+			if !shouldRetry {
+				if c.retryLog != nil {
+					c.retryLog(attempt, 0, nil, err)
+				}
+				return nil, err
+			}
+			if err := c.sleepBeforeRetry(ctx, attempt, resp); err != nil {
+				return nil, err
 			}
-			time.Sleep(time.Duration(attempt+1) * 500 * time.Millisecond)
 			continue
 		}
-		defer resp.Body.Close()
 
 		// Handle HTTP errors
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 			body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
 			errMsg := resp.Status + " body: " + string(body)
-			
-			// If we get a server error, try next mirror
-			if resp.StatusCode >= 500 {
-				c.rotateMirror()
-				lastErr = errors.New(errMsg)
-				continue
+			lastErr = errors.New(errMsg)
+
+			// A 4xx means the mirror itself is healthy, it just didn't like
+			// this request; only 5xx/network trouble should trip the
+			// circuit breaker.
+			c.recordMirrorResult(idx, resp.StatusCode < 500, latency)
+
+			if !shouldRetry {
+				// For client errors (4xx) the policy doesn't want a retry.
+				if c.retryLog != nil {
+					c.retryLog(attempt, 0, resp, lastErr)
+				}
+				return nil, lastErr
 			}
-			
-			// For client errors (4xx), don't retry with different mirror
-			return "", errors.New(errMsg)
-		}
-		
-		// Process successful response
-		var reader io.Reader = resp.Body
-		if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
-			gz, err := gzip.NewReader(resp.Body)
-			if err != nil {
-				return "", err
+
+			if err := c.sleepBeforeRetry(ctx, attempt, resp); err != nil {
+				return nil, err
 			}
-			defer gz.Close()
-			reader = gz
+			continue
 		}
-		
-		b, err := io.ReadAll(reader)
+
+		c.recordMirrorResult(idx, true, latency)
+
+		reader, err := decodeBody(resp)
 		if err != nil {
-			return "", err
+			resp.Body.Close()
+			return nil, err
 		}
-		
-		return string(b), nil
+
+		if c.retryLog != nil {
+			c.retryLog(attempt, 0, resp, nil)
+		}
+
+		return &Response{
+			statusCode: resp.StatusCode,
+			header:     resp.Header,
+			body:       reader,
+			closer:     resp.Body,
+		}, nil
+	}
+
+	return nil, errors.New("all mirrors failed, last error: " + lastErr.Error())
+}
+
+// sleepBeforeRetry computes the backoff for the given attempt via the
+// client's RetryPolicy, reports it through the RetryLog hook (if any),
+// and blocks for that duration or until ctx is cancelled, whichever comes
+// first.
+func (c *Client) sleepBeforeRetry(ctx context.Context, attempt int, resp *http.Response) error {
+	wait := c.retryPolicy.Backoff(c.retryWaitMin, c.retryWaitMax, attempt, resp)
+	if c.retryLog != nil {
+		c.retryLog(attempt, wait, resp, nil)
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
 	}
-	
-	return "", errors.New("all mirrors failed, last error: " + lastErr.Error())
 }
 
 // parseCookieHeader parses cookie header string into http.Cookie array
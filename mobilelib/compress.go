@@ -0,0 +1,29 @@
+package mobilelib
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decodeBody wraps resp.Body in a decompressing reader based on
+// Content-Encoding (gzip, deflate, br), or returns the raw body unchanged
+// if the encoding is absent or not one we understand. The caller is
+// responsible for closing resp.Body once done (decompressing readers here
+// don't own the underlying connection).
+func decodeBody(resp *http.Response) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		return flate.NewReader(resp.Body), nil
+	case "br":
+		return brotli.NewReader(resp.Body), nil
+	default:
+		return resp.Body, nil
+	}
+}
@@ -0,0 +1,113 @@
+package mobilelib
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestDecodeBodyGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write([]byte("hello gzip")); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+
+	resp := httptest.NewRecorder().Result()
+	resp.Header.Set("Content-Encoding", "gzip")
+	resp.Body = io.NopCloser(&buf)
+
+	r, err := decodeBody(resp)
+	if err != nil {
+		t.Fatalf("decodeBody: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading decoded body: %v", err)
+	}
+	if string(got) != "hello gzip" {
+		t.Fatalf("got %q, want %q", got, "hello gzip")
+	}
+}
+
+func TestDecodeBodyDeflate(t *testing.T) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		t.Fatalf("flate.NewWriter: %v", err)
+	}
+	if _, err := fw.Write([]byte("hello deflate")); err != nil {
+		t.Fatalf("flate write: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("flate close: %v", err)
+	}
+
+	resp := httptest.NewRecorder().Result()
+	resp.Header.Set("Content-Encoding", "deflate")
+	resp.Body = io.NopCloser(&buf)
+
+	r, err := decodeBody(resp)
+	if err != nil {
+		t.Fatalf("decodeBody: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading decoded body: %v", err)
+	}
+	if string(got) != "hello deflate" {
+		t.Fatalf("got %q, want %q", got, "hello deflate")
+	}
+}
+
+func TestDecodeBodyBrotli(t *testing.T) {
+	var buf bytes.Buffer
+	bw := brotli.NewWriter(&buf)
+	if _, err := bw.Write([]byte("hello brotli")); err != nil {
+		t.Fatalf("brotli write: %v", err)
+	}
+	if err := bw.Close(); err != nil {
+		t.Fatalf("brotli close: %v", err)
+	}
+
+	resp := httptest.NewRecorder().Result()
+	resp.Header.Set("Content-Encoding", "br")
+	resp.Body = io.NopCloser(&buf)
+
+	r, err := decodeBody(resp)
+	if err != nil {
+		t.Fatalf("decodeBody: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading decoded body: %v", err)
+	}
+	if string(got) != "hello brotli" {
+		t.Fatalf("got %q, want %q", got, "hello brotli")
+	}
+}
+
+func TestDecodeBodyNoEncodingPassesThrough(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewBufferString("plain"))}
+
+	r, err := decodeBody(resp)
+	if err != nil {
+		t.Fatalf("decodeBody: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if string(got) != "plain" {
+		t.Fatalf("got %q, want %q", got, "plain")
+	}
+}
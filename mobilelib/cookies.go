@@ -0,0 +1,331 @@
+package mobilelib
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CookieJar is an http.CookieJar that delegates request-time cookie
+// matching to the stdlib cookiejar.Jar (so Domain/Path/Secure scoping
+// stays correct) but also keeps its own index of every cookie it has
+// seen, full attributes included, because cookiejar.Jar has no way to
+// enumerate its contents. That index backs the Netscape/JSON export and
+// the optional on-disk persistence Android's WebView login flow needs.
+type CookieJar struct {
+	mu      sync.Mutex
+	std     *cookiejar.Jar
+	entries map[string]*http.Cookie // key: domain|path|name
+	path    string                  // PersistCookies file, empty disables persistence
+}
+
+// newCookieJar creates a CookieJar, loading previously persisted cookies
+// from persistPath if it's non-empty and the file exists.
+func newCookieJar(persistPath string) (*CookieJar, error) {
+	std, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &CookieJar{std: std, entries: make(map[string]*http.Cookie), path: persistPath}
+
+	if persistPath != "" {
+		data, err := os.ReadFile(persistPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("loading persisted cookies: %w", err)
+		}
+		if err == nil {
+			if err := j.SetCookiesJSON(data); err != nil {
+				return nil, fmt.Errorf("parsing persisted cookies: %w", err)
+			}
+		}
+	}
+
+	return j, nil
+}
+
+// SetCookies implements http.CookieJar. It updates the stdlib jar used for
+// real requests, records the cookies (with full attributes) in the
+// exportable index, and atomically flushes to disk if PersistCookies was
+// configured.
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.std.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	for _, c := range cookies {
+		domain := c.Domain
+		if domain == "" {
+			domain = u.Hostname()
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		key := domain + "|" + path + "|" + c.Name
+
+		if c.MaxAge < 0 || (c.MaxAge == 0 && !c.Expires.IsZero() && c.Expires.Before(time.Now())) {
+			delete(j.entries, key)
+			continue
+		}
+
+		stored := *c
+		stored.Domain = domain
+		stored.Path = path
+		// Max-Age takes precedence over Expires per RFC 6265, and modern
+		// servers (especially session/login cookies) commonly send only
+		// Max-Age. Without resolving it to an absolute Expires here, the
+		// export/import round-trip below would treat it as a session
+		// cookie and lose it entirely across a process restart.
+		if stored.MaxAge > 0 {
+			stored.Expires = time.Now().Add(time.Duration(stored.MaxAge) * time.Second)
+		}
+		j.entries[key] = &stored
+	}
+	j.mu.Unlock()
+
+	if j.path != "" {
+		if err := j.persist(); err != nil {
+			// Best-effort: a failed flush shouldn't break the request that
+			// triggered it, the in-memory jar (used for real requests) is
+			// already up to date regardless.
+			_ = err
+		}
+	}
+}
+
+// Cookies implements http.CookieJar by delegating to the stdlib jar.
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.std.Cookies(u)
+}
+
+// persist atomically writes the current cookie index to j.path as JSON.
+func (j *CookieJar) persist() error {
+	data, err := j.ExportCookiesJSON()
+	if err != nil {
+		return err
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// ExportCookiesNetscape writes every cookie in the jar in the Netscape
+// cookies.txt format (7 tab-separated fields, one cookie per line), the
+// format curl/wget and most browser extensions use.
+func (j *CookieJar) ExportCookiesNetscape(w io.Writer) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Netscape HTTP Cookie File")
+
+	for _, c := range j.entries {
+		linePrefix := ""
+		if c.HttpOnly {
+			linePrefix = "#HttpOnly_"
+		}
+		includeSubdomains := "FALSE"
+		if strings.HasPrefix(c.Domain, ".") {
+			includeSubdomains = "TRUE"
+		}
+		secure := "FALSE"
+		if c.Secure {
+			secure = "TRUE"
+		}
+		expiry := "0"
+		if !c.Expires.IsZero() {
+			expiry = strconv.FormatInt(c.Expires.Unix(), 10)
+		}
+
+		fmt.Fprintf(bw, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			linePrefix, c.Domain, includeSubdomains, c.Path, secure, expiry, c.Name, c.Value)
+	}
+
+	return bw.Flush()
+}
+
+// SetCookiesFromNetscape reads cookies in the Netscape cookies.txt format
+// and adds them to the jar (including re-deriving Domain/Path/Secure/
+// HttpOnly, which the plain "k=v; k2=v2" SetCookieString cannot).
+func (j *CookieJar) SetCookiesFromNetscape(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			httpOnly = true
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		} else if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+
+		domain, _, path, secureStr, expiryStr, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+		var expires time.Time
+		if expirySecs, err := strconv.ParseInt(expiryStr, 10, 64); err == nil && expirySecs > 0 {
+			expires = time.Unix(expirySecs, 0)
+		}
+
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Domain:   domain,
+			Path:     path,
+			Secure:   secureStr == "TRUE",
+			HttpOnly: httpOnly,
+			Expires:  expires,
+		}
+		j.SetCookies(cookieOriginURL(cookie), []*http.Cookie{cookie})
+	}
+	return scanner.Err()
+}
+
+// jsonCookie mirrors the fields EditThisCookie and Playwright's
+// storage_state both use, so jars can be imported from or exported to
+// either without extra conversion.
+type jsonCookie struct {
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Domain         string  `json:"domain"`
+	Path           string  `json:"path"`
+	ExpirationDate float64 `json:"expirationDate,omitempty"`
+	HostOnly       bool    `json:"hostOnly"`
+	HTTPOnly       bool    `json:"httpOnly"`
+	Secure         bool    `json:"secure"`
+	Session        bool    `json:"session"`
+}
+
+// ExportCookiesJSON returns every cookie in the jar as JSON compatible
+// with EditThisCookie/Playwright cookie exports.
+func (j *CookieJar) ExportCookiesJSON() ([]byte, error) {
+	j.mu.Lock()
+	out := make([]jsonCookie, 0, len(j.entries))
+	for _, c := range j.entries {
+		jc := jsonCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			HostOnly: !strings.HasPrefix(c.Domain, "."),
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if c.Expires.IsZero() {
+			jc.Session = true
+		} else {
+			jc.ExpirationDate = float64(c.Expires.Unix())
+		}
+		out = append(out, jc)
+	}
+	j.mu.Unlock()
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// SetCookiesJSON imports cookies from an EditThisCookie/Playwright-style
+// JSON array.
+func (j *CookieJar) SetCookiesJSON(data []byte) error {
+	var in []jsonCookie
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	for _, jc := range in {
+		cookie := &http.Cookie{
+			Name:     jc.Name,
+			Value:    jc.Value,
+			Domain:   jc.Domain,
+			Path:     jc.Path,
+			Secure:   jc.Secure,
+			HttpOnly: jc.HTTPOnly,
+		}
+		if !jc.Session && jc.ExpirationDate > 0 {
+			cookie.Expires = time.Unix(int64(jc.ExpirationDate), 0)
+		}
+		j.SetCookies(cookieOriginURL(cookie), []*http.Cookie{cookie})
+	}
+	return nil
+}
+
+// cookieOriginURL builds the URL cookiejar.Jar needs as the "setting" URL
+// for a cookie whose Domain/Secure we already know (as opposed to a live
+// response, where the URL is the request URL and Domain may be implicit).
+func cookieOriginURL(c *http.Cookie) *url.URL {
+	scheme := "http"
+	if c.Secure {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: strings.TrimPrefix(c.Domain, ".")}
+}
+
+// SetCookiesFromHeader parses one or more raw Set-Cookie header values via
+// (*http.Response).Cookies (so Domain/Path/Expires/Secure/HttpOnly round-trip
+// correctly), unlike SetCookieString's simplistic "k=v; k2=v2" splitter,
+// which only exists because that's all Android's CookieManager.getCookie
+// exposes in the first place.
+func (c *Client) SetCookiesFromHeader(u *url.URL, setCookieHeader []string) error {
+	if c == nil || c.jar == nil {
+		return errors.New("jar is nil")
+	}
+	resp := &http.Response{Header: http.Header{"Set-Cookie": setCookieHeader}}
+	c.jar.SetCookies(u, resp.Cookies())
+	return nil
+}
+
+// SetCookiesFromNetscape imports cookies in the Netscape cookies.txt
+// format into the client's jar.
+func (c *Client) SetCookiesFromNetscape(r io.Reader) error {
+	if c == nil || c.jar == nil {
+		return errors.New("jar is nil")
+	}
+	return c.jar.SetCookiesFromNetscape(r)
+}
+
+// ExportCookiesNetscape writes the client's jar in the Netscape
+// cookies.txt format.
+func (c *Client) ExportCookiesNetscape(w io.Writer) error {
+	if c == nil || c.jar == nil {
+		return errors.New("jar is nil")
+	}
+	return c.jar.ExportCookiesNetscape(w)
+}
+
+// SetCookiesJSON imports cookies from an EditThisCookie/Playwright-style
+// JSON array into the client's jar.
+func (c *Client) SetCookiesJSON(data []byte) error {
+	if c == nil || c.jar == nil {
+		return errors.New("jar is nil")
+	}
+	return c.jar.SetCookiesJSON(data)
+}
+
+// ExportCookiesJSON returns the client's jar as EditThisCookie/Playwright-
+// compatible JSON.
+func (c *Client) ExportCookiesJSON() ([]byte, error) {
+	if c == nil || c.jar == nil {
+		return nil, errors.New("jar is nil")
+	}
+	return c.jar.ExportCookiesJSON()
+}
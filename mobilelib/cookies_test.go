@@ -0,0 +1,103 @@
+package mobilelib
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCookieJarMaxAgeOnlyCookieSurvivesJSONRoundTrip(t *testing.T) {
+	j, err := newCookieJar("")
+	if err != nil {
+		t.Fatalf("newCookieJar: %v", err)
+	}
+
+	u := &url.URL{Scheme: "https", Host: "rutracker.org"}
+	j.SetCookies(u, []*http.Cookie{{Name: "bb_session", Value: "abc123", MaxAge: 3600}})
+
+	data, err := j.ExportCookiesJSON()
+	if err != nil {
+		t.Fatalf("ExportCookiesJSON: %v", err)
+	}
+
+	j2, err := newCookieJar("")
+	if err != nil {
+		t.Fatalf("newCookieJar: %v", err)
+	}
+	if err := j2.SetCookiesJSON(data); err != nil {
+		t.Fatalf("SetCookiesJSON: %v", err)
+	}
+
+	found := false
+	for _, c := range j2.entries {
+		if c.Name != "bb_session" {
+			continue
+		}
+		found = true
+		if c.Expires.IsZero() {
+			t.Fatal("Max-Age-only cookie lost its expiry across a JSON round-trip")
+		}
+		if c.Expires.Before(time.Now().Add(30 * time.Minute)) {
+			t.Fatalf("Expires %v too soon for a 3600s Max-Age cookie", c.Expires)
+		}
+	}
+	if !found {
+		t.Fatal("bb_session cookie did not survive the round-trip")
+	}
+}
+
+func TestCookieJarMaxAgeOnlyCookieSurvivesNetscapeRoundTrip(t *testing.T) {
+	j, err := newCookieJar("")
+	if err != nil {
+		t.Fatalf("newCookieJar: %v", err)
+	}
+
+	u := &url.URL{Scheme: "https", Host: "rutracker.org"}
+	j.SetCookies(u, []*http.Cookie{{Name: "bb_session", Value: "abc123", MaxAge: 3600}})
+
+	var buf bytes.Buffer
+	if err := j.ExportCookiesNetscape(&buf); err != nil {
+		t.Fatalf("ExportCookiesNetscape: %v", err)
+	}
+
+	j2, err := newCookieJar("")
+	if err != nil {
+		t.Fatalf("newCookieJar: %v", err)
+	}
+	if err := j2.SetCookiesFromNetscape(&buf); err != nil {
+		t.Fatalf("SetCookiesFromNetscape: %v", err)
+	}
+
+	found := false
+	for _, c := range j2.entries {
+		if c.Name != "bb_session" {
+			continue
+		}
+		found = true
+		if c.Expires.IsZero() {
+			t.Fatal("Max-Age-only cookie was exported with Netscape expiry 0 (session cookie)")
+		}
+	}
+	if !found {
+		t.Fatal("bb_session cookie did not survive the Netscape round-trip")
+	}
+}
+
+func TestCookieJarNegativeMaxAgeDeletesCookie(t *testing.T) {
+	j, err := newCookieJar("")
+	if err != nil {
+		t.Fatalf("newCookieJar: %v", err)
+	}
+
+	u := &url.URL{Scheme: "https", Host: "rutracker.org"}
+	j.SetCookies(u, []*http.Cookie{{Name: "bb_session", Value: "abc123", MaxAge: 3600}})
+	j.SetCookies(u, []*http.Cookie{{Name: "bb_session", Value: "", MaxAge: -1}})
+
+	for _, c := range j.entries {
+		if c.Name == "bb_session" {
+			t.Fatal("cookie with MaxAge < 0 should have been deleted")
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package mobilelib
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// mirrorFailureThreshold is the number of consecutive failures that
+	// trips a mirror's circuit breaker open.
+	mirrorFailureThreshold = 3
+
+	// mirrorBaseCooldown is the initial open-state duration; it doubles
+	// with each further consecutive failure past mirrorFailureThreshold
+	// (capped at mirrorMaxCooldown).
+	mirrorBaseCooldown = 15 * time.Second
+	mirrorMaxCooldown  = 5 * time.Minute
+
+	// mirrorLatencyEWMAAlpha weights how quickly recent latency samples
+	// override the running average.
+	mirrorLatencyEWMAAlpha = 0.3
+)
+
+// mirrorHealth tracks the circuit-breaker state and latency score for one
+// mirror host.
+type mirrorHealth struct {
+	mu sync.Mutex
+
+	host                string
+	ewmaLatency         time.Duration
+	consecutiveFailures int
+	open                bool
+	openUntil           time.Time
+	probing             bool // half-open: a probe request is already in flight
+}
+
+// MirrorStat is the exported snapshot of a mirror's health, for Flutter to
+// render in a mirror-status UI.
+type MirrorStat struct {
+	Host                string
+	EWMALatency         time.Duration
+	ConsecutiveFailures int
+	Open                bool
+	OpenUntil           time.Time
+}
+
+// snapshot returns a copy of the current health state for GetMirrorStats.
+func (h *mirrorHealth) snapshot() MirrorStat {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return MirrorStat{
+		Host:                h.host,
+		EWMALatency:         h.ewmaLatency,
+		ConsecutiveFailures: h.consecutiveFailures,
+		Open:                h.open,
+		OpenUntil:           h.openUntil,
+	}
+}
+
+// available reports whether a request may currently be sent to this
+// mirror, without mutating any state. A mirror whose cooldown has elapsed
+// is reported available (the single half-open probe slot is claimed
+// separately, via beginProbe, only for the mirror pickMirror actually
+// dispatches to) so that merely scanning candidates can't itself consume
+// the probe slot of a mirror that loses the score comparison.
+func (h *mirrorHealth) available(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.open {
+		return true
+	}
+	if now.Before(h.openUntil) {
+		return false
+	}
+	return !h.probing
+}
+
+// beginProbe claims the single half-open probe slot for a mirror whose
+// cooldown has elapsed, so only the mirror pickMirror actually sends a
+// request to is marked probing. recordResult clears probing once that
+// request completes, whether it succeeds or fails.
+func (h *mirrorHealth) beginProbe(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.open && !now.Before(h.openUntil) {
+		h.probing = true
+	}
+}
+
+// score ranks mirrors for selection: lower is better. Untested mirrors
+// (zero EWMA) sort first so a fresh mirror gets a chance before we trust
+// latency history, matching how a browser's own connection racing would
+// behave on first use.
+func (h *mirrorHealth) score() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.ewmaLatency
+}
+
+// recordResult folds a request outcome into the mirror's health: latency
+// EWMA on success, consecutive-failure counting and circuit state on
+// failure.
+func (h *mirrorHealth) recordResult(success bool, latency time.Duration, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.probing = false
+
+	if success {
+		h.consecutiveFailures = 0
+		h.open = false
+		if h.ewmaLatency == 0 {
+			h.ewmaLatency = latency
+		} else {
+			h.ewmaLatency = time.Duration(mirrorLatencyEWMAAlpha*float64(latency) + (1-mirrorLatencyEWMAAlpha)*float64(h.ewmaLatency))
+		}
+		return
+	}
+
+	h.consecutiveFailures++
+	if h.consecutiveFailures < mirrorFailureThreshold {
+		return
+	}
+
+	cooldown := mirrorBaseCooldown << uint(h.consecutiveFailures-mirrorFailureThreshold)
+	if cooldown > mirrorMaxCooldown || cooldown <= 0 {
+		cooldown = mirrorMaxCooldown
+	}
+	h.open = true
+	h.openUntil = now.Add(cooldown)
+}
+
+// pickMirror returns the index of the best available mirror: the lowest
+// latency score among mirrors whose circuit isn't open, or the mirror
+// closest to recovering if every circuit is currently open (so a total
+// tracker outage doesn't wedge the client with no host to try at all).
+func (c *Client) pickMirror() int {
+	now := time.Now()
+
+	best := -1
+	var bestScore time.Duration
+	fallback := 0
+	fallbackSet := false
+	fallbackOpenUntil := time.Time{}
+
+	for i, h := range c.mirrorHealth {
+		snap := h.snapshot()
+		if !fallbackSet || snap.OpenUntil.Before(fallbackOpenUntil) {
+			fallback = i
+			fallbackSet = true
+			fallbackOpenUntil = snap.OpenUntil
+		}
+
+		if !h.available(now) {
+			continue
+		}
+
+		score := h.score()
+		if best == -1 || score < bestScore {
+			best = i
+			bestScore = score
+		}
+	}
+
+	if best == -1 {
+		c.mirrorHealth[fallback].beginProbe(now)
+		return fallback
+	}
+	c.mirrorHealth[best].beginProbe(now)
+	return best
+}
+
+// recordMirrorResult updates mirror index idx's health after a request.
+func (c *Client) recordMirrorResult(idx int, success bool, latency time.Duration) {
+	if idx < 0 || idx >= len(c.mirrorHealth) {
+		return
+	}
+	c.mirrorHealth[idx].recordResult(success, latency, time.Now())
+}
+
+// GetMirrorStats returns a health snapshot of every configured mirror, for
+// Flutter to render mirror status in the UI.
+func (c *Client) GetMirrorStats() []MirrorStat {
+	stats := make([]MirrorStat, len(c.mirrorHealth))
+	for i, h := range c.mirrorHealth {
+		stats[i] = h.snapshot()
+	}
+	return stats
+}
+
+// GetMirrorStats reports the default client's mirror health.
+func GetMirrorStats() []MirrorStat {
+	if defaultClient == nil {
+		return nil
+	}
+	return defaultClient.GetMirrorStats()
+}
+
+// startHealthProbes runs a background HEAD "/" probe against every mirror
+// every interval, so a mirror that recovered on its own gets marked
+// healthy again without needing to wait on real user traffic to retry it.
+// It stops when ctx is cancelled.
+func (c *Client) startHealthProbes(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.probeMirrors(ctx)
+			}
+		}
+	}()
+}
+
+func (c *Client) probeMirrors(ctx context.Context) {
+	for i, host := range c.mirrors {
+		go func(idx int, host string) {
+			start := time.Now()
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://"+host+"/", nil)
+			if err != nil {
+				return
+			}
+			req.Header.Set("User-Agent", c.ua)
+
+			resp, err := c.http.Do(req)
+			latency := time.Since(start)
+			if err != nil {
+				c.recordMirrorResult(idx, false, latency)
+				return
+			}
+			defer resp.Body.Close()
+			c.recordMirrorResult(idx, resp.StatusCode < 500, latency)
+		}(i, host)
+	}
+}
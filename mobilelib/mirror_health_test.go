@@ -0,0 +1,101 @@
+package mobilelib
+
+import (
+	"testing"
+	"time"
+)
+
+func newOpenHealth(host string, openUntil time.Time) *mirrorHealth {
+	return &mirrorHealth{host: host, open: true, openUntil: openUntil, consecutiveFailures: mirrorFailureThreshold}
+}
+
+func TestMirrorHealthTripsOpenAfterThreshold(t *testing.T) {
+	h := &mirrorHealth{host: "a"}
+	now := time.Now()
+
+	for i := 0; i < mirrorFailureThreshold-1; i++ {
+		h.recordResult(false, 0, now)
+		if h.open {
+			t.Fatalf("circuit opened after only %d failures, want %d", i+1, mirrorFailureThreshold)
+		}
+	}
+	h.recordResult(false, 0, now)
+	if !h.open {
+		t.Fatalf("circuit did not open after %d consecutive failures", mirrorFailureThreshold)
+	}
+	if !h.openUntil.After(now) {
+		t.Fatal("openUntil should be in the future once the circuit trips")
+	}
+}
+
+func TestMirrorHealthScanningDoesNotLockOutLosingCandidate(t *testing.T) {
+	now := time.Now()
+
+	// Two mirrors, both past cooldown (half-open eligible). "slow" has a
+	// worse (higher) score so it loses the comparison in pickMirror-style
+	// scanning, but scanning alone must not consume its probe slot.
+	fast := newOpenHealth("fast", now.Add(-time.Second))
+	fast.ewmaLatency = 10 * time.Millisecond
+	slow := newOpenHealth("slow", now.Add(-time.Second))
+	slow.ewmaLatency = 500 * time.Millisecond
+
+	mirrors := []*mirrorHealth{fast, slow}
+
+	// Simulate pickMirror's scanning pass: call available() on every
+	// candidate while comparing scores, without calling beginProbe on the
+	// loser.
+	var best *mirrorHealth
+	for _, h := range mirrors {
+		if !h.available(now) {
+			continue
+		}
+		if best == nil || h.score() < best.score() {
+			best = h
+		}
+	}
+	if best != fast {
+		t.Fatalf("expected fast mirror to win selection")
+	}
+	best.beginProbe(now)
+
+	if slow.probing {
+		t.Fatal("scanning a losing candidate must not mark it as probing")
+	}
+	if !slow.available(now) {
+		t.Fatal("losing candidate must remain available for a future pick")
+	}
+}
+
+func TestMirrorHealthBeginProbeClearsOnResult(t *testing.T) {
+	now := time.Now()
+	h := newOpenHealth("a", now.Add(-time.Second))
+
+	h.beginProbe(now)
+	if !h.probing {
+		t.Fatal("beginProbe should claim the half-open probe slot")
+	}
+	if h.available(now) {
+		t.Fatal("a second caller must not see the mirror as available while a probe is in flight")
+	}
+
+	h.recordResult(true, 5*time.Millisecond, now)
+	if h.probing {
+		t.Fatal("recordResult should clear probing")
+	}
+	if h.open {
+		t.Fatal("a successful probe should close the circuit")
+	}
+}
+
+func TestMirrorHealthAvailableDoesNotMutateState(t *testing.T) {
+	now := time.Now()
+	h := newOpenHealth("a", now.Add(-time.Second))
+
+	h.available(now)
+	h.available(now)
+	h.available(now)
+
+	if h.probing {
+		t.Fatal("available() must be read-only and never set probing itself")
+	}
+}
@@ -0,0 +1,52 @@
+package mobilelib
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBuildProxyTransportSocks5H(t *testing.T) {
+	base := &http.Transport{}
+
+	rt, err := buildProxyTransport(base, "socks5h://127.0.0.1:9050")
+	if err != nil {
+		t.Fatalf("socks5h:// should build a transport like socks5://, got error: %v", err)
+	}
+
+	t2, ok := rt.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", rt)
+	}
+	if t2.DialContext == nil {
+		t.Fatal("expected a SOCKS5 DialContext to be installed")
+	}
+}
+
+func TestBuildProxyTransportSocks5(t *testing.T) {
+	base := &http.Transport{}
+
+	if _, err := buildProxyTransport(base, "socks5://127.0.0.1:9050"); err != nil {
+		t.Fatalf("socks5:// should build a transport without error, got: %v", err)
+	}
+}
+
+func TestBuildProxyTransportHTTP(t *testing.T) {
+	base := &http.Transport{}
+
+	rt, err := buildProxyTransport(base, "http://127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("http:// should build a transport without error, got: %v", err)
+	}
+	t2 := rt.(*http.Transport)
+	if t2.Proxy == nil {
+		t.Fatal("expected http.ProxyURL to be installed for an http:// proxy")
+	}
+}
+
+func TestBuildProxyTransportUnsupportedScheme(t *testing.T) {
+	base := &http.Transport{}
+
+	if _, err := buildProxyTransport(base, "ftp://127.0.0.1:21"); err == nil {
+		t.Fatal("expected an error for an unsupported proxy scheme")
+	}
+}
@@ -0,0 +1,173 @@
+package mobilelib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// RequestBuilder is a chainable builder for requests against the current
+// client, e.g.:
+//
+//	resp, err := c.Request().Path("/forum/tracker.php").Query("nm", term).Get(ctx)
+//	resp, err := c.Request().Path("/login.php").Form("login_username", u).Form("login_password", p).Post(ctx)
+//
+// It reuses the client's mirror rotation, health-scored selection, retry
+// policy, and response decompression.
+type RequestBuilder struct {
+	c       *Client
+	path    string
+	query   url.Values
+	header  http.Header
+	cookies []*http.Cookie
+
+	form      url.Values
+	jsonBody  interface{}
+	fileParts []filePart
+}
+
+type filePart struct {
+	field    string
+	filename string
+	r        io.Reader
+}
+
+// Request starts a new fluent request against c.
+func (c *Client) Request() *RequestBuilder {
+	return &RequestBuilder{c: c, header: http.Header{}}
+}
+
+// Path sets the request path, e.g. "/forum/tracker.php".
+func (b *RequestBuilder) Path(path string) *RequestBuilder {
+	b.path = path
+	return b
+}
+
+// Query adds a URL query parameter.
+func (b *RequestBuilder) Query(key, value string) *RequestBuilder {
+	if b.query == nil {
+		b.query = url.Values{}
+	}
+	b.query.Add(key, value)
+	return b
+}
+
+// Form adds a form field. Presence of any form field makes the request
+// body application/x-www-form-urlencoded, unless JSON or File have also
+// been used, which take precedence.
+func (b *RequestBuilder) Form(key, value string) *RequestBuilder {
+	if b.form == nil {
+		b.form = url.Values{}
+	}
+	b.form.Add(key, value)
+	return b
+}
+
+// JSON sets the request body to the JSON encoding of v.
+func (b *RequestBuilder) JSON(v interface{}) *RequestBuilder {
+	b.jsonBody = v
+	return b
+}
+
+// File adds a file part to a multipart/form-data body, e.g. for uploading
+// a .torrent file. Presence of any file part takes precedence over Form
+// and JSON.
+func (b *RequestBuilder) File(field, filename string, r io.Reader) *RequestBuilder {
+	b.fileParts = append(b.fileParts, filePart{field: field, filename: filename, r: r})
+	return b
+}
+
+// Header sets a request header.
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.header.Set(key, value)
+	return b
+}
+
+// Cookie attaches an extra cookie to the request (in addition to whatever
+// the client's cookie jar already carries for this host).
+func (b *RequestBuilder) Cookie(cookie *http.Cookie) *RequestBuilder {
+	b.cookies = append(b.cookies, cookie)
+	return b
+}
+
+// Get sends the request with GET.
+func (b *RequestBuilder) Get(ctx context.Context) (*Response, error) {
+	return b.Do(ctx, http.MethodGet)
+}
+
+// Post sends the request with POST.
+func (b *RequestBuilder) Post(ctx context.Context) (*Response, error) {
+	return b.Do(ctx, http.MethodPost)
+}
+
+// Do sends the request with the given method.
+func (b *RequestBuilder) Do(ctx context.Context, method string) (*Response, error) {
+	body, contentType, err := b.buildBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.c.doRequest(ctx, requestSpec{
+		method:      method,
+		path:        b.path,
+		query:       b.query,
+		header:      b.header,
+		cookies:     b.cookies,
+		body:        body,
+		contentType: contentType,
+	})
+}
+
+// buildBody resolves the request body and Content-Type from whichever of
+// File/JSON/Form was used, in that precedence order (a file part implies
+// multipart, which can't be mixed with a plain form-urlencoded body).
+func (b *RequestBuilder) buildBody() ([]byte, string, error) {
+	switch {
+	case len(b.fileParts) > 0:
+		return b.buildMultipart()
+	case b.jsonBody != nil:
+		buf, err := json.Marshal(b.jsonBody)
+		if err != nil {
+			return nil, "", fmt.Errorf("encoding JSON body: %w", err)
+		}
+		return buf, "application/json", nil
+	case len(b.form) > 0:
+		return []byte(b.form.Encode()), "application/x-www-form-urlencoded", nil
+	default:
+		return nil, "", nil
+	}
+}
+
+func (b *RequestBuilder) buildMultipart() ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for key, values := range b.form {
+		for _, v := range values {
+			if err := w.WriteField(key, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	for _, part := range b.fileParts {
+		fw, err := w.CreateFormFile(part.field, part.filename)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := io.Copy(fw, part.r); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
@@ -0,0 +1,100 @@
+package mobilelib
+
+import (
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBuildBodyFormOnly(t *testing.T) {
+	b := &RequestBuilder{header: make(map[string][]string)}
+	b.Form("nm", "some torrent")
+
+	body, contentType, err := b.buildBody()
+	if err != nil {
+		t.Fatalf("buildBody: %v", err)
+	}
+	if contentType != "application/x-www-form-urlencoded" {
+		t.Fatalf("got Content-Type %q", contentType)
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatalf("parsing form body: %v", err)
+	}
+	if values.Get("nm") != "some torrent" {
+		t.Fatalf("got form body %q", body)
+	}
+}
+
+func TestBuildBodyJSONTakesPrecedenceOverForm(t *testing.T) {
+	b := &RequestBuilder{header: make(map[string][]string)}
+	b.Form("nm", "ignored")
+	b.JSON(map[string]string{"q": "some torrent"})
+
+	body, contentType, err := b.buildBody()
+	if err != nil {
+		t.Fatalf("buildBody: %v", err)
+	}
+	if contentType != "application/json" {
+		t.Fatalf("got Content-Type %q, want application/json", contentType)
+	}
+	if !strings.Contains(string(body), `"q":"some torrent"`) {
+		t.Fatalf("got JSON body %s", body)
+	}
+}
+
+func TestBuildBodyFileTakesPrecedenceOverJSONAndForm(t *testing.T) {
+	b := &RequestBuilder{header: make(map[string][]string)}
+	b.Form("nm", "kept")
+	b.JSON(map[string]string{"q": "ignored"})
+	b.File("torrent", "test.torrent", strings.NewReader("d8:announce..."))
+
+	body, contentType, err := b.buildBody()
+	if err != nil {
+		t.Fatalf("buildBody: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("parsing Content-Type %q: %v", contentType, err)
+	}
+	if mediaType != "multipart/form-data" {
+		t.Fatalf("got media type %q, want multipart/form-data", mediaType)
+	}
+
+	reader := multipart.NewReader(strings.NewReader(string(body)), params["boundary"])
+	var sawFile, sawForm bool
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		if part.FormName() == "torrent" {
+			sawFile = true
+		}
+		if part.FormName() == "nm" {
+			sawForm = true
+		}
+	}
+	if !sawFile {
+		t.Fatal("multipart body is missing the file part")
+	}
+	if !sawForm {
+		t.Fatal("multipart body is missing the form field alongside the file part")
+	}
+}
+
+func TestBuildBodyEmpty(t *testing.T) {
+	b := &RequestBuilder{header: make(map[string][]string)}
+
+	body, contentType, err := b.buildBody()
+	if err != nil {
+		t.Fatalf("buildBody: %v", err)
+	}
+	if body != nil || contentType != "" {
+		t.Fatalf("expected empty body/content-type, got %q/%q", body, contentType)
+	}
+}
@@ -0,0 +1,54 @@
+package mobilelib
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Response wraps the result of a request made through RequestBuilder (or
+// GetText). The body is already decompressed (gzip/deflate/br) but not yet
+// read, so callers can choose how to consume it: buffered via Text/Bytes/
+// JSON, or streamed via Save for large payloads like torrent files.
+type Response struct {
+	statusCode int
+	header     http.Header
+	body       io.Reader
+	closer     io.Closer
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (r *Response) StatusCode() int {
+	return r.statusCode
+}
+
+// Header returns the response headers.
+func (r *Response) Header() http.Header {
+	return r.header
+}
+
+// Bytes reads the entire (decompressed) body into memory and closes it.
+func (r *Response) Bytes() ([]byte, error) {
+	defer r.closer.Close()
+	return io.ReadAll(r.body)
+}
+
+// Text reads the entire body as a string and closes it.
+func (r *Response) Text() (string, error) {
+	b, err := r.Bytes()
+	return string(b), err
+}
+
+// JSON decodes the body into v and closes it.
+func (r *Response) JSON(v interface{}) error {
+	defer r.closer.Close()
+	return json.NewDecoder(r.body).Decode(v)
+}
+
+// Save streams the body directly into w without buffering it all in
+// memory, and closes it afterwards. Use this for large downloads (e.g.
+// .torrent payloads) instead of Bytes.
+func (r *Response) Save(w io.Writer) (int64, error) {
+	defer r.closer.Close()
+	return io.Copy(w, r.body)
+}
@@ -0,0 +1,80 @@
+package mobilelib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type trackingCloser struct {
+	closed bool
+}
+
+func (c *trackingCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestResponseTextClosesBody(t *testing.T) {
+	closer := &trackingCloser{}
+	r := &Response{statusCode: 200, body: strings.NewReader("hello"), closer: closer}
+
+	got, err := r.Text()
+	if err != nil {
+		t.Fatalf("Text: %v", err)
+	}
+	if got != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+	if !closer.closed {
+		t.Fatal("Text should close the body")
+	}
+}
+
+func TestResponseJSONDecodesAndCloses(t *testing.T) {
+	closer := &trackingCloser{}
+	r := &Response{body: strings.NewReader(`{"name":"rutracker"}`), closer: closer}
+
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err := r.JSON(&v); err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	if v.Name != "rutracker" {
+		t.Fatalf("got %q, want %q", v.Name, "rutracker")
+	}
+	if !closer.closed {
+		t.Fatal("JSON should close the body")
+	}
+}
+
+func TestResponseSaveStreamsAndCloses(t *testing.T) {
+	closer := &trackingCloser{}
+	payload := strings.Repeat("torrent-bytes", 1000)
+	r := &Response{body: strings.NewReader(payload), closer: closer}
+
+	var out bytes.Buffer
+	n, err := r.Save(&out)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if n != int64(len(payload)) {
+		t.Fatalf("Save wrote %d bytes, want %d", n, len(payload))
+	}
+	if out.String() != payload {
+		t.Fatal("Save did not stream the full payload")
+	}
+	if !closer.closed {
+		t.Fatal("Save should close the body")
+	}
+}
+
+func TestResponseJSONInvalidBody(t *testing.T) {
+	r := &Response{body: strings.NewReader("not json"), closer: &trackingCloser{}}
+
+	var v map[string]string
+	if err := r.JSON(&v); err == nil {
+		t.Fatal("expected an error decoding invalid JSON")
+	}
+}
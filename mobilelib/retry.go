@@ -0,0 +1,167 @@
+package mobilelib
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckRetryFunc decides whether a request should be retried given the
+// response and/or error from the previous attempt. Returning a non-nil
+// error aborts the retry loop immediately with that error.
+type CheckRetryFunc func(ctx context.Context, resp *http.Response, err error) (bool, error)
+
+// BackoffFunc computes how long to sleep before the next attempt.
+type BackoffFunc func(min, max time.Duration, attempt int, resp *http.Response) time.Duration
+
+// RetryPolicy bundles the retry decision and backoff strategy, mirroring
+// the CheckRetry/Backoff split used by go-retryablehttp.
+type RetryPolicy struct {
+	CheckRetry CheckRetryFunc
+	Backoff    BackoffFunc
+}
+
+// RetryLogFunc is called after each attempt so callers (e.g. the Flutter
+// bridge) can surface retry telemetry to the user.
+type RetryLogFunc func(attempt int, wait time.Duration, resp *http.Response, err error)
+
+// DefaultRetryPolicy returns the policy every Client is built with:
+// exponential backoff with full jitter, Retry-After aware, and a
+// network/5xx-focused CheckRetry. MirrorConfig has no field to replace it.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		CheckRetry: DefaultCheckRetry,
+		Backoff:    DefaultBackoff,
+	}
+}
+
+// DefaultCheckRetry retries on retryable network errors and on 429/5xx
+// responses. It does not retry on context cancellation/deadline or on
+// other 4xx responses.
+func DefaultCheckRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	if err != nil {
+		if isRetryableNetworkError(err) {
+			return true, nil
+		}
+		return false, nil
+	}
+
+	if resp == nil {
+		return false, nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true, nil
+	}
+	if resp.StatusCode >= 500 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// DefaultBackoff implements exponential backoff with full jitter:
+// sleep = random(0, min(max, min*2^attempt)), as described in
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+// A Retry-After header on the response (seconds or HTTP-date) takes
+// precedence over the computed value, per request.
+func DefaultBackoff(min, max time.Duration, attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfterDuration(resp); ok {
+			if wait > max {
+				return max
+			}
+			return wait
+		}
+	}
+
+	ceiling := float64(max)
+	base := float64(min) * math.Pow(2, float64(attempt))
+	if base > ceiling || base <= 0 {
+		base = ceiling
+	}
+
+	return time.Duration(rand.Int63n(int64(base) + 1))
+}
+
+// retryAfterDuration parses the Retry-After header (RFC 7231, section
+// 7.1.3), which rutracker's rate limiter sets on 429/503 responses.
+func retryAfterDuration(resp *http.Response) (time.Duration, bool) {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(strings.TrimSpace(h)); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if when, err := http.ParseTime(h); err == nil {
+		wait := time.Until(when)
+		if wait < 0 {
+			return 0, true
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// isRetryableNetworkError distinguishes transient network failures (DNS,
+// connection refused, EOF, TLS handshake) from permanent ones that should
+// fail fast.
+func isRetryableNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	var tlsErr *tls.RecordHeaderError
+	if errors.As(err, &tlsErr) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, substr := range []string{
+		"connection refused",
+		"connection reset",
+		"no such host",
+		"timeout",
+		"eof",
+		"tls handshake",
+		"broken pipe",
+	} {
+		if strings.Contains(strings.ToLower(msg), substr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,78 @@
+package mobilelib
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDefaultBackoffCapsAtMax(t *testing.T) {
+	min := 100 * time.Millisecond
+	max := 1 * time.Second
+
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := DefaultBackoff(min, max, attempt, nil)
+		if wait < 0 || wait > max {
+			t.Fatalf("attempt %d: wait %v out of range [0, %v]", attempt, wait, max)
+		}
+	}
+}
+
+func TestDefaultBackoffHonorsRetryAfterSeconds(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"2"}}}
+	wait := DefaultBackoff(100*time.Millisecond, 10*time.Second, 0, resp)
+	if wait != 2*time.Second {
+		t.Fatalf("expected 2s from Retry-After, got %v", wait)
+	}
+}
+
+func TestDefaultBackoffRetryAfterCappedAtMax(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"30"}}}
+	wait := DefaultBackoff(100*time.Millisecond, 5*time.Second, 0, resp)
+	if wait != 5*time.Second {
+		t.Fatalf("expected Retry-After to be capped at max 5s, got %v", wait)
+	}
+}
+
+func TestRetryAfterDurationParsesHTTPDate(t *testing.T) {
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{future}}}
+
+	wait, ok := retryAfterDuration(resp)
+	if !ok {
+		t.Fatal("expected Retry-After HTTP-date to parse")
+	}
+	if wait <= 0 || wait > 11*time.Second {
+		t.Fatalf("unexpected wait %v derived from HTTP-date", wait)
+	}
+}
+
+func TestRetryAfterDurationMissingHeader(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	if _, ok := retryAfterDuration(resp); ok {
+		t.Fatal("expected no Retry-After to report ok=false")
+	}
+}
+
+func TestIsRetryableNetworkError(t *testing.T) {
+	cases := []struct {
+		msg  string
+		want bool
+	}{
+		{"dial tcp: connection refused", true},
+		{"unexpected EOF", true},
+		{"tls handshake timeout", true},
+		{"permission denied", false},
+	}
+
+	for _, tc := range cases {
+		got := isRetryableNetworkError(errMsg(tc.msg))
+		if got != tc.want {
+			t.Errorf("isRetryableNetworkError(%q) = %v, want %v", tc.msg, got, tc.want)
+		}
+	}
+}
+
+type errMsg string
+
+func (e errMsg) Error() string { return string(e) }
@@ -0,0 +1,325 @@
+package mobilelib
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+	"golang.org/x/net/http2"
+)
+
+// namedTLSFingerprints maps the friendly MirrorConfig.TLSFingerprint values
+// to a uTLS ClientHelloID. Raw JA3 strings (detected by the presence of
+// commas) bypass this table and go through ja3ToClientHelloSpec instead.
+// Each name maps to an existing uTLS preset, not always the exact version
+// in the name: "firefox-esr" sends Firefox 105's ClientHello and
+// "safari-ios-17" sends iOS 14's.
+var namedTLSFingerprints = map[string]utls.ClientHelloID{
+	"chrome-120":    utls.HelloChrome_120,
+	"firefox-esr":   utls.HelloFirefox_105,
+	"safari-ios-17": utls.HelloIOS_14,
+}
+
+// fingerprintRoundTripper dials with a uTLS ClientHello matching the
+// configured browser profile instead of Go's own TLS fingerprint, and
+// hands the connection to an HTTP/2 transport when the server negotiates
+// "h2" over ALPN. It exists because http.Transport's usual
+// http2.ConfigureTransport wiring assumes a *tls.Conn from crypto/tls,
+// which a uTLS handshake does not produce.
+//
+// Known gap: h2 is a stock golang.org/x/net/http2.Transport, whose initial
+// SETTINGS frame has a fixed order/content that isn't configurable per
+// profile. Reordering it to match the chosen browser's SETTINGS, as the
+// fingerprint spoofing this type exists for would ideally also do, is not
+// implemented — it would require driving the HTTP/2 handshake by hand
+// instead of handing the conn to http2.Transport.
+type fingerprintRoundTripper struct {
+	h1   *http.Transport
+	h2   *http2.Transport
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	mu         sync.Mutex
+	negotiated map[string]string // host -> ALPN protocol, so repeat requests skip the probe dial
+}
+
+func newFingerprintRoundTripper(fingerprint string, base *http.Transport) (http.RoundTripper, error) {
+	helloID, helloSpec, err := parseTLSFingerprint(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialUTLS(ctx, network, addr, helloID, helloSpec)
+	}
+
+	h1 := base.Clone()
+	h1.TLSClientConfig = nil // uTLS owns the handshake, stdlib must not redo it
+	h1.DialTLSContext = dial
+
+	h2 := &http2.Transport{
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+
+	return &fingerprintRoundTripper{h1: h1, h2: h2, dial: dial, negotiated: make(map[string]string)}, nil
+}
+
+func (rt *fingerprintRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+
+	rt.mu.Lock()
+	proto, known := rt.negotiated[host]
+	rt.mu.Unlock()
+
+	if !known {
+		var err error
+		proto, err = rt.probeALPN(req.Context(), host)
+		if err != nil {
+			return nil, err
+		}
+		rt.mu.Lock()
+		rt.negotiated[host] = proto
+		rt.mu.Unlock()
+	}
+
+	if proto == "h2" {
+		return rt.h2.RoundTrip(req)
+	}
+	return rt.h1.RoundTrip(req)
+}
+
+// probeALPN performs the same uTLS handshake the real request's transport
+// will do, purely to learn which protocol ALPN negotiated before the first
+// request to host is dispatched. Without this, a host that negotiates "h2"
+// would have its first request sent as plain HTTP/1.1 over a connection the
+// server already believes is framed as HTTP/2.
+func (rt *fingerprintRoundTripper) probeALPN(ctx context.Context, host string) (string, error) {
+	addr := host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "443")
+	}
+
+	conn, err := rt.dial(ctx, "tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	uconn, ok := conn.(*utls.UConn)
+	if !ok {
+		return "http/1.1", nil
+	}
+
+	proto := uconn.ConnectionState().NegotiatedProtocol
+	if proto == "" {
+		proto = "http/1.1"
+	}
+	return proto, nil
+}
+
+// dialUTLS dials network/addr and performs a uTLS handshake that mimics
+// helloID's (or helloSpec's, for custom JA3-derived specs) ClientHello:
+// cipher order, extensions, curves, and ALPN offer. It does not, and
+// cannot by itself, affect HTTP/2 SETTINGS frame order — that frame is
+// emitted later by h2 (a stock http2.Transport) and is not reordered to
+// match the profile; see the known-gap note on fingerprintRoundTripper.
+func dialUTLS(ctx context.Context, network, addr string, helloID utls.ClientHelloID, helloSpec *utls.ClientHelloSpec) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 60 * time.Second}
+
+	rawConn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	sni := addr
+	if host, _, splitErr := net.SplitHostPort(addr); splitErr == nil {
+		sni = host
+	}
+
+	uconn := utls.UClient(rawConn, &utls.Config{ServerName: sni}, helloID)
+	if helloSpec != nil {
+		if applyErr := uconn.ApplyPreset(helloSpec); applyErr != nil {
+			rawConn.Close()
+			return nil, fmt.Errorf("applying JA3 ClientHello spec: %w", applyErr)
+		}
+	}
+
+	if hsErr := uconn.HandshakeContext(ctx); hsErr != nil {
+		rawConn.Close()
+		return nil, fmt.Errorf("uTLS handshake: %w", hsErr)
+	}
+
+	return uconn, nil
+}
+
+// parseTLSFingerprint resolves MirrorConfig.TLSFingerprint into a uTLS
+// ClientHelloID, and, for raw JA3 strings, a ClientHelloSpec built to
+// match it. An empty fingerprint is invalid here; callers must keep the
+// stdlib transport (the default) instead of calling this.
+func parseTLSFingerprint(fingerprint string) (utls.ClientHelloID, *utls.ClientHelloSpec, error) {
+	if id, ok := namedTLSFingerprints[fingerprint]; ok {
+		return id, nil, nil
+	}
+
+	if strings.Contains(fingerprint, ",") {
+		spec, err := ja3ToClientHelloSpec(fingerprint)
+		if err != nil {
+			return utls.ClientHelloID{}, nil, fmt.Errorf("parsing JA3 fingerprint: %w", err)
+		}
+		return utls.HelloCustom, spec, nil
+	}
+
+	return utls.ClientHelloID{}, nil, fmt.Errorf("unknown TLS fingerprint %q", fingerprint)
+}
+
+// ja3ToClientHelloSpec parses the 5-field JA3 string format
+// (TLSVersion,Ciphers,Extensions,EllipticCurves,EllipticCurvePointFormats,
+// dash-separated within each field) into a uTLS ClientHelloSpec. Extension
+// IDs we don't recognize are still included via GenericExtension so the
+// wire-level extension ordering matches the source JA3 exactly, even if
+// uTLS can't interpret their contents.
+func ja3ToClientHelloSpec(ja3 string) (*utls.ClientHelloSpec, error) {
+	fields := strings.Split(ja3, ",")
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 comma-separated fields, got %d", len(fields))
+	}
+
+	version, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("TLS version: %w", err)
+	}
+
+	ciphers, err := ja3IntList(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("cipher list: %w", err)
+	}
+
+	extensionIDs, err := ja3IntList(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("extension list: %w", err)
+	}
+
+	curves, err := ja3IntList(fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("elliptic curve list: %w", err)
+	}
+
+	pointFormats, err := ja3IntList(fields[4])
+	if err != nil {
+		return nil, fmt.Errorf("point format list: %w", err)
+	}
+
+	cipherSuites := make([]uint16, len(ciphers))
+	for i, c := range ciphers {
+		cipherSuites[i] = uint16(c)
+	}
+
+	// JA3's legacy version field (fields[0]) is the ClientHello.version,
+	// which real browsers pin to TLS 1.2 ("771") for middlebox compatibility
+	// even when they actually negotiate TLS 1.3 via extension 43
+	// (supported_versions). Deriving TLSVersMax from fields[0] alone would
+	// cap the spec at TLS 1.2 while still advertising TLS 1.3 in that
+	// extension below — exactly the internal mismatch a JA3 check looks for.
+	versMax := uint16(version)
+	for _, extID := range extensionIDs {
+		if extID == 43 {
+			versMax = utls.VersionTLS13
+			break
+		}
+	}
+
+	spec := &utls.ClientHelloSpec{
+		TLSVersMin:         uint16(version),
+		TLSVersMax:         versMax,
+		CipherSuites:       cipherSuites,
+		CompressionMethods: []byte{0}, // JA3 always assumes null compression
+		Extensions:         make([]utls.TLSExtension, 0, len(extensionIDs)),
+	}
+
+	curveIDs := make([]utls.CurveID, len(curves))
+	for i, c := range curves {
+		curveIDs[i] = utls.CurveID(c)
+	}
+
+	formats := make([]byte, len(pointFormats))
+	for i, p := range pointFormats {
+		formats[i] = byte(p)
+	}
+
+	for _, extID := range extensionIDs {
+		switch extID {
+		case 0:
+			spec.Extensions = append(spec.Extensions, &utls.SNIExtension{})
+		case 10:
+			spec.Extensions = append(spec.Extensions, &utls.SupportedCurvesExtension{Curves: curveIDs})
+		case 11:
+			spec.Extensions = append(spec.Extensions, &utls.SupportedPointsExtension{SupportedPoints: formats})
+		case 13:
+			spec.Extensions = append(spec.Extensions, &utls.SignatureAlgorithmsExtension{
+				SupportedSignatureAlgorithms: []utls.SignatureScheme{
+					utls.ECDSAWithP256AndSHA256,
+					utls.PSSWithSHA256,
+					utls.PKCS1WithSHA256,
+					utls.ECDSAWithP384AndSHA384,
+					utls.PSSWithSHA384,
+					utls.PKCS1WithSHA384,
+					utls.PSSWithSHA512,
+					utls.PKCS1WithSHA512,
+				},
+			})
+		case 16:
+			spec.Extensions = append(spec.Extensions, &utls.ALPNExtension{AlpnProtocols: []string{"h2", "http/1.1"}})
+		case 23:
+			spec.Extensions = append(spec.Extensions, &utls.UtlsExtendedMasterSecretExtension{})
+		case 43:
+			spec.Extensions = append(spec.Extensions, &utls.SupportedVersionsExtension{
+				Versions: []uint16{utls.VersionTLS13, utls.VersionTLS12},
+			})
+		case 45:
+			spec.Extensions = append(spec.Extensions, &utls.PSKKeyExchangeModesExtension{
+				Modes: []uint8{utls.PskModeDHE},
+			})
+		case 51:
+			spec.Extensions = append(spec.Extensions, &utls.KeyShareExtension{
+				KeyShares: []utls.KeyShare{{Group: utls.X25519}},
+			})
+		case 65281:
+			spec.Extensions = append(spec.Extensions, &utls.RenegotiationInfoExtension{})
+		default:
+			// Preserve the extension's place in the ClientHello even though
+			// we don't know how to build its payload; an empty body is
+			// enough to keep the byte-level extension ORDER matching JA3,
+			// which is what most fingerprinters actually compare.
+			spec.Extensions = append(spec.Extensions, &utls.GenericExtension{Id: uint16(extID)})
+		}
+	}
+
+	return spec, nil
+}
+
+// ja3IntList parses a dash-separated list of decimal integers, e.g.
+// "4865-4866-4867". An empty field (JA3 uses this for "no extensions")
+// yields an empty, non-nil slice.
+func ja3IntList(field string) ([]int, error) {
+	if field == "" {
+		return []int{}, nil
+	}
+	parts := strings.Split(field, "-")
+	out := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package mobilelib
+
+import (
+	"testing"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+func TestJA3ToClientHelloSpecTLS13ViaExtension43(t *testing.T) {
+	// Real Chrome JA3: legacy version 771 (TLS 1.2) but extension 43
+	// (supported_versions) advertises TLS 1.3.
+	ja3 := "771,4865-4866-4867,0-23-65281-10-11-35-16-5-13-43-45-51,29-23-24,0"
+
+	spec, err := ja3ToClientHelloSpec(ja3)
+	if err != nil {
+		t.Fatalf("ja3ToClientHelloSpec returned error: %v", err)
+	}
+
+	if spec.TLSVersMax != utls.VersionTLS13 {
+		t.Fatalf("TLSVersMax = %#x, want TLS 1.3 (%#x) since extension 43 is present", spec.TLSVersMax, utls.VersionTLS13)
+	}
+	if spec.TLSVersMin != 0x0303 {
+		t.Fatalf("TLSVersMin = %#x, want legacy ClientHello version 0x0303", spec.TLSVersMin)
+	}
+}
+
+func TestJA3ToClientHelloSpecNoSupportedVersionsExtension(t *testing.T) {
+	ja3 := "771,4865-4866-4867,0-23-65281,29-23-24,0"
+
+	spec, err := ja3ToClientHelloSpec(ja3)
+	if err != nil {
+		t.Fatalf("ja3ToClientHelloSpec returned error: %v", err)
+	}
+
+	if spec.TLSVersMax != 0x0303 {
+		t.Fatalf("TLSVersMax = %#x, want legacy version 0x0303 when extension 43 is absent", spec.TLSVersMax)
+	}
+}
+
+func TestJA3ToClientHelloSpecRejectsWrongFieldCount(t *testing.T) {
+	if _, err := ja3ToClientHelloSpec("771,4865-4866"); err == nil {
+		t.Fatal("expected error for JA3 string with too few fields")
+	}
+}
+
+func TestJA3IntListEmptyField(t *testing.T) {
+	out, err := ja3IntList("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 0 {
+		t.Fatalf("expected empty slice, got %v", out)
+	}
+}
+
+func TestJA3IntListParsesDashSeparated(t *testing.T) {
+	out, err := ja3IntList("4865-4866-4867")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []int{4865, 4866, 4867}
+	if len(out) != len(want) {
+		t.Fatalf("got %v, want %v", out, want)
+	}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Fatalf("got %v, want %v", out, want)
+		}
+	}
+}